@@ -0,0 +1,88 @@
+// Command wavgen synthesizes placeholder WAV files (silence, a tone,
+// or white noise) without ever materializing the whole PCM buffer in
+// memory.
+//
+// Usage:
+//
+//	wavgen -silence 30s -rate 48000 -channels 2 -bits 24 out.wav
+//	wavgen -sine 440 -duration 5s out.wav
+//	wavgen -noise -duration 5s out.wav
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ffmiruz/wav"
+	"github.com/ffmiruz/wav/generator"
+)
+
+func main() {
+	var (
+		silence  = flag.Duration("silence", 0, "emit silence for this long")
+		sineFreq = flag.Float64("sine", 0, "emit a sine tone at this frequency (Hz)")
+		noise    = flag.Bool("noise", false, "emit white noise")
+		duration = flag.Duration("duration", 0, "duration for -sine/-noise")
+		rate     = flag.Int("rate", 44100, "sample rate")
+		channels = flag.Int("channels", 2, "channel count")
+		bits     = flag.Int("bits", 16, "bits per sample")
+	)
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: wavgen [flags] out.wav")
+		os.Exit(1)
+	}
+
+	fc := wav.FmtChunk{
+		AudioFormat:   wav.AudioFormatPCM,
+		Channel:       uint16(*channels),
+		SampleRate:    uint32(*rate),
+		BitsPerSample: uint16(*bits),
+		ByteRate:      uint32(*rate * *channels * *bits / 8),
+		BlockAlign:    uint16(*channels * *bits / 8),
+	}
+
+	var src wav.SampleSource
+	switch {
+	case *silence > 0:
+		src = generator.Silence(*silence, fc)
+	case *sineFreq > 0:
+		src = generator.Sine(*sineFreq, dur(*duration), fc)
+	case *noise:
+		src = generator.WhiteNoise(dur(*duration), fc)
+	default:
+		fmt.Fprintln(os.Stderr, "wavgen: one of -silence, -sine or -noise is required")
+		os.Exit(1)
+	}
+
+	out, err := os.Create(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer out.Close()
+
+	enc := wav.NewEncoder(out)
+	if err := enc.WriteHeader(fc, 0); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := enc.WriteFrom(src); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := enc.Finalize(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func dur(d time.Duration) time.Duration {
+	if d == 0 {
+		return 5 * time.Second
+	}
+	return d
+}
@@ -0,0 +1,106 @@
+// Package codec is the pivot point between this module's streaming
+// WAV encoder/decoder and other PCM codecs. Third-party modules (FLAC,
+// TTA, ALAC, ...) register a DecoderFactory/EncoderFactory pair
+// keyed by file extension; callers then use Open/Create without
+// caring which codec backs a given path:
+//
+//	src, err := codec.Open("in.flac")
+//	defer src.Close()
+//	dst, err := codec.Create("out.wav", src.Format())
+//	defer dst.Close()
+//	err = dst.WriteFrom(src)
+//
+// Every registered codec speaks wav.SampleSource, so the WAV encoder
+// handles the RIFF plumbing while the codec adapter only has to
+// produce or consume PCM frames. WriteFrom finalizes the output
+// before returning, so the file is valid as soon as it succeeds;
+// Close only needs to be called to release the underlying handle.
+package codec
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ffmiruz/wav"
+)
+
+// Decoder is a codec's read side: a SampleSource plus a Close to
+// release whatever file handle or resource backs it.
+type Decoder interface {
+	wav.SampleSource
+	Close() error
+}
+
+// Encoder is a codec's write side: something that can drain a
+// SampleSource into an output file. WriteFrom implementations must
+// finalize the file (flushing any size fields that depend on the
+// total frame count) before returning; Close only needs to release
+// the underlying handle.
+type Encoder interface {
+	WriteFrom(src wav.SampleSource) error
+	Close() error
+}
+
+// DecoderFactory opens path for reading in a codec's native format.
+type DecoderFactory func(path string) (Decoder, error)
+
+// EncoderFactory creates path for writing in a codec's native format,
+// using fc to describe the PCM layout that will be written to it.
+type EncoderFactory func(path string, fc wav.FmtChunk) (Encoder, error)
+
+type registration struct {
+	dec DecoderFactory
+	enc EncoderFactory
+}
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]registration{}
+)
+
+// Register associates name (e.g. "wav", "flac", "tta") with the
+// factories used to open/create files in that format. Register is
+// typically called from a codec package's init function.
+func Register(name string, dec DecoderFactory, enc EncoderFactory) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry[name] = registration{dec: dec, enc: enc}
+}
+
+func lookup(path string) (registration, error) {
+	name := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	mu.RLock()
+	reg, ok := registry[name]
+	mu.RUnlock()
+	if !ok {
+		return registration{}, fmt.Errorf("codec: no codec registered for %q", name)
+	}
+	return reg, nil
+}
+
+// Open opens path for reading, picking a codec by its file extension.
+func Open(path string) (Decoder, error) {
+	reg, err := lookup(path)
+	if err != nil {
+		return nil, err
+	}
+	if reg.dec == nil {
+		return nil, fmt.Errorf("codec: %q has no registered decoder", filepath.Ext(path))
+	}
+	return reg.dec(path)
+}
+
+// Create creates path for writing in fc's format, picking a codec by
+// its file extension.
+func Create(path string, fc wav.FmtChunk) (Encoder, error) {
+	reg, err := lookup(path)
+	if err != nil {
+		return nil, err
+	}
+	if reg.enc == nil {
+		return nil, fmt.Errorf("codec: %q has no registered encoder", filepath.Ext(path))
+	}
+	return reg.enc(path, fc)
+}
@@ -0,0 +1,76 @@
+package codec
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ffmiruz/wav"
+	"github.com/ffmiruz/wav/generator"
+)
+
+func TestOpenCreateRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.wav")
+
+	fc := wav.FmtChunk{
+		AudioFormat:   wav.AudioFormatPCM,
+		Channel:       1,
+		SampleRate:    8000,
+		BitsPerSample: 16,
+		BlockAlign:    2,
+		ByteRate:      16000,
+	}
+
+	enc, err := Create(path, fc)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	src := generator.Silence(5*time.Millisecond, fc)
+	if err := enc.WriteFrom(src); err != nil {
+		t.Fatalf("WriteFrom: %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// The file must already be a valid, correctly-sized WAV after
+	// WriteFrom returns, before Close is ever called.
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if info.Size() <= 44 {
+		t.Fatalf("file size = %d, want > 44 (header-only would mean Finalize never ran)", info.Size())
+	}
+
+	dec, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer dec.Close()
+
+	dst := [][]int32{make([]int32, 64)}
+	total := 0
+	for {
+		n, err := dec.ReadSamples(dst)
+		total += n
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ReadSamples: %v", err)
+		}
+	}
+	if total != 40 { // 5ms @ 8000Hz
+		t.Fatalf("decoded %d frames, want 40", total)
+	}
+}
+
+func TestOpenUnregisteredExtension(t *testing.T) {
+	if _, err := Open("missing.flac"); err == nil {
+		t.Fatal("Open(\"missing.flac\") succeeded, want error for unregistered codec")
+	}
+}
@@ -0,0 +1,69 @@
+package codec
+
+import (
+	"os"
+
+	"github.com/ffmiruz/wav"
+)
+
+func init() {
+	Register("wav", openWAV, createWAV)
+}
+
+type wavDecoder struct {
+	f   *os.File
+	dec *wav.Decoder
+}
+
+func openWAV(path string) (Decoder, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	dec, err := wav.NewDecoder(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &wavDecoder{f: f, dec: dec}, nil
+}
+
+func (d *wavDecoder) Format() wav.FmtChunk { return d.dec.Format() }
+
+func (d *wavDecoder) ReadSamples(dst [][]int32) (int, error) {
+	return d.dec.ReadSamples(dst, len(dst[0]))
+}
+
+func (d *wavDecoder) Close() error { return d.f.Close() }
+
+type wavEncoder struct {
+	f   *os.File
+	enc *wav.Encoder
+}
+
+func createWAV(path string, fc wav.FmtChunk) (Encoder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	enc := wav.NewEncoder(f)
+	if err := enc.WriteHeader(fc, 0); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &wavEncoder{f: f, enc: enc}, nil
+}
+
+// WriteFrom drains src and finalizes the RIFF/data chunk sizes before
+// returning, so the file on disk is valid as soon as WriteFrom
+// succeeds; Close only needs to release the file handle.
+func (e *wavEncoder) WriteFrom(src wav.SampleSource) error {
+	if err := e.enc.WriteFrom(src); err != nil {
+		return err
+	}
+	return e.enc.Finalize()
+}
+
+func (e *wavEncoder) Close() error {
+	return e.f.Close()
+}
@@ -2,8 +2,7 @@ package main
 
 import (
 	"fmt"
-	"github.com/ffmiyo/wav"
-	"io/ioutil"
+	"github.com/ffmiruz/wav"
 	"os"
 )
 
@@ -15,13 +14,45 @@ func main() {
 	}
 	defer file.Close()
 
-	chunk, _ := wav.Unmarshal(file)
-
-	buf := wav.Marshal(chunk)
+	dec, err := wav.NewDecoder(file)
+	if err != nil {
+		panic(err)
+	}
 
-	err = ioutil.WriteFile("copy.wav", buf, 0644)
+	out, err := os.Create("copy.wav")
 	if err != nil {
 		panic(err)
 	}
+	defer out.Close()
+
+	enc := wav.NewEncoder(out)
+	if err := enc.WriteHeader(dec.Format(), 0); err != nil {
+		panic(err)
+	}
+
+	fc := dec.Format()
+	const blockFrames = 4096
+	block := make([][]int32, fc.Channel)
+	for i := range block {
+		block[i] = make([]int32, blockFrames)
+	}
+	for {
+		n, err := dec.ReadSamples(block, blockFrames)
+		if n > 0 {
+			frames := make([][]int32, fc.Channel)
+			for i := range frames {
+				frames[i] = block[i][:n]
+			}
+			if err := enc.WriteSamples(frames); err != nil {
+				panic(err)
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
 
+	if err := enc.Finalize(); err != nil {
+		panic(err)
+	}
 }
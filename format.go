@@ -0,0 +1,284 @@
+package wav
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// WAVE_FORMAT_* audio format codes, as found in FmtChunk.AudioFormat.
+const (
+	AudioFormatPCM        uint16 = 0x0001
+	AudioFormatIEEEFloat  uint16 = 0x0003
+	AudioFormatALaw       uint16 = 0x0006
+	AudioFormatMULaw      uint16 = 0x0007
+	AudioFormatExtensible uint16 = 0xFFFE
+)
+
+// ASCII string "RF64"
+const RF64_TAG uint32 = 0x52463634
+
+// ASCII string "ds64"
+const ds64_TAG uint32 = 0x64733634
+
+// Extended fields of a WAVE_FORMAT_EXTENSIBLE fmt chunk, whose body is
+// 40 bytes with cbSize == 22. A fmt chunk with a smaller cbSize
+// (including the cbSize == 0 that plain non-PCM chunks carry) gets no
+// FmtExtension. SubFormat holds the GUID whose first two bytes are the
+// real WAVE_FORMAT_* code when AudioFormat == AudioFormatExtensible.
+type FmtExtension struct {
+	ValidBitsPerSample uint16
+	ChannelMask        uint32
+	SubFormat          [16]byte
+}
+
+// ds64 chunk, present in RF64/BW64 files in place of the 32-bit RIFF
+// header size, used once the data chunk would otherwise overflow
+// uint32 (>4 GiB). RiffSize/DataSize/SampleCount mirror the fields the
+// 32-bit RIFF/data chunks would otherwise carry.
+type ds64Chunk struct {
+	RiffSize    uint64
+	DataSize    uint64
+	SampleCount uint64
+}
+
+func (fm *FmtChunk) unmarshal(buf []byte) error {
+	if len(buf) < 16 {
+		return errors.New("fmt chunk too short")
+	}
+	fm.AudioFormat = binary.LittleEndian.Uint16(buf[0:2])
+	fm.Channel = binary.LittleEndian.Uint16(buf[2:4])
+	fm.SampleRate = binary.LittleEndian.Uint32(buf[4:8])
+	fm.ByteRate = binary.LittleEndian.Uint32(buf[8:12])
+	fm.BlockAlign = binary.LittleEndian.Uint16(buf[12:14])
+	fm.BitsPerSample = binary.LittleEndian.Uint16(buf[14:16])
+	if len(buf) < 18 {
+		return nil
+	}
+	cbSize := binary.LittleEndian.Uint16(buf[16:18])
+	if cbSize < 22 || len(buf) < 18+int(cbSize) {
+		return nil
+	}
+	ext := &FmtExtension{}
+	ext.ValidBitsPerSample = binary.LittleEndian.Uint16(buf[18:20])
+	ext.ChannelMask = binary.LittleEndian.Uint32(buf[20:24])
+	copy(ext.SubFormat[:], buf[24:40])
+	fm.Extension = ext
+	if fm.AudioFormat == AudioFormatExtensible {
+		fm.AudioFormat = binary.LittleEndian.Uint16(ext.SubFormat[0:2])
+	}
+	return nil
+}
+
+func (fm FmtChunk) marshal(buf []byte) {
+	audioFormat := fm.AudioFormat
+	if fm.Extension != nil {
+		audioFormat = AudioFormatExtensible
+	}
+	binary.LittleEndian.PutUint16(buf[0:2], audioFormat)
+	binary.LittleEndian.PutUint16(buf[2:4], fm.Channel)
+	binary.LittleEndian.PutUint32(buf[4:8], fm.SampleRate)
+	binary.LittleEndian.PutUint32(buf[8:12], fm.ByteRate)
+	binary.LittleEndian.PutUint16(buf[12:14], fm.BlockAlign)
+	binary.LittleEndian.PutUint16(buf[14:16], fm.BitsPerSample)
+	if fm.Extension == nil {
+		return
+	}
+	binary.LittleEndian.PutUint16(buf[16:18], 22)
+	binary.LittleEndian.PutUint16(buf[18:20], fm.Extension.ValidBitsPerSample)
+	binary.LittleEndian.PutUint32(buf[20:24], fm.Extension.ChannelMask)
+	subFormat := fm.Extension.SubFormat
+	binary.LittleEndian.PutUint16(subFormat[0:2], fm.AudioFormat)
+	copy(buf[24:40], subFormat[:])
+}
+
+// chunkSize returns the on-disk size of this fmt sub-chunk: 16 for
+// plain PCM/IEEE float/A-law/mu-law, 40 for WAVE_FORMAT_EXTENSIBLE.
+func (fm FmtChunk) chunkSize() uint32 {
+	if fm.Extension != nil {
+		return 40
+	}
+	return 16
+}
+
+func (d *ds64Chunk) unmarshal(r io.Reader) error {
+	buf := make([]byte, 28)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return errors.New("fail to read ds64 chunk")
+	}
+	d.RiffSize = binary.LittleEndian.Uint64(buf[0:8])
+	d.DataSize = binary.LittleEndian.Uint64(buf[8:16])
+	d.SampleCount = binary.LittleEndian.Uint64(buf[16:24])
+	return nil
+}
+
+// alawDecode and mulawDecode convert a single G.711 companded byte to
+// a 16-bit linear PCM sample per ITU-T G.711.
+func alawDecode(b byte) int16 {
+	b ^= 0x55
+	sign := b & 0x80
+	exponent := (b >> 4) & 0x07
+	mantissa := b & 0x0F
+	sample := int32(mantissa) << 4
+	sample += 8
+	if exponent != 0 {
+		sample += 0x100
+		sample <<= uint(exponent - 1)
+	}
+	if sign == 0 {
+		sample = -sample
+	}
+	return int16(sample)
+}
+
+func mulawDecode(b byte) int16 {
+	b = ^b
+	sign := b & 0x80
+	exponent := (b >> 4) & 0x07
+	mantissa := b & 0x0F
+	sample := (int32(mantissa)<<3 + 0x84) << uint(exponent)
+	sample -= 0x84
+	if sign != 0 {
+		sample = -sample
+	}
+	return int16(sample)
+}
+
+// clamp4 restricts v to the 4-bit mantissa range used by alawEncode
+// and mulawEncode.
+func clamp4(v int32) byte {
+	if v < 0 {
+		return 0
+	}
+	if v > 15 {
+		return 15
+	}
+	return byte(v)
+}
+
+// alawEncode and mulawEncode invert alawDecode and mulawDecode,
+// companding a 16-bit linear PCM sample down to a single G.711 byte.
+// Like all 8-bit companding, this is lossy: quantization error grows
+// with the exponent (segment) the sample falls into.
+func alawEncode(sample int32) byte {
+	sign := byte(0x80)
+	mag := sample
+	if mag < 0 {
+		sign = 0
+		mag = -mag
+	}
+	if mag > 32256 {
+		mag = 32256
+	}
+	var exponent, mantissa byte
+	if mag <= 248 {
+		mantissa = clamp4((mag + 8) >> 4)
+	} else {
+		exponent = 1
+		for exponent < 7 && mag > int32(504)<<(exponent-1) {
+			exponent++
+		}
+		mantissa = clamp4((mag>>(exponent-1) - 264 + 8) >> 4)
+	}
+	return (sign | exponent<<4 | mantissa) ^ 0x55
+}
+
+func mulawEncode(sample int32) byte {
+	const bias = 0x84
+	const clip = 32635
+	sign := byte(0)
+	if sample < 0 {
+		sign = 0x80
+		sample = -sample
+	}
+	if sample > clip {
+		sample = clip
+	}
+	sample += bias
+	exponent := byte(7)
+	for mask := int32(0x4000); sample&mask == 0 && exponent > 0; mask >>= 1 {
+		exponent--
+	}
+	mantissa := byte((sample >> (uint(exponent) + 3)) & 0x0F)
+	return ^(sign | exponent<<4 | mantissa)
+}
+
+// Samples holds fully decoded, channel-major sample data read via
+// ReadAll. It exists alongside the streaming Decoder for callers who
+// want a typed, in-memory view and don't mind the file fitting in
+// memory.
+type Samples struct {
+	fmt FmtChunk
+	raw [][]int32 // one slice per channel; meaning depends on fmt.AudioFormat/BitsPerSample
+}
+
+// ReadAll decodes every frame of r into memory and returns the fmt
+// chunk alongside the raw samples. Prefer Decoder.ReadSamples for
+// large files.
+func ReadAll(r io.Reader) (FmtChunk, Samples, error) {
+	dec, err := NewDecoder(r)
+	if err != nil {
+		return FmtChunk{}, Samples{}, err
+	}
+	fc := dec.Format()
+	out := make([][]int32, fc.Channel)
+	const blockFrames = 4096
+	block := make([][]int32, fc.Channel)
+	for i := range block {
+		block[i] = make([]int32, blockFrames)
+	}
+	for {
+		n, err := dec.ReadSamples(block, blockFrames)
+		for ch := range out {
+			out[ch] = append(out[ch], block[ch][:n]...)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fc, Samples{}, err
+		}
+	}
+	return fc, Samples{fmt: fc, raw: out}, nil
+}
+
+// SamplesInt16 returns the decoded samples as 16-bit signed integers,
+// companded G.711 and 8-bit PCM samples included.
+func (s Samples) SamplesInt16() [][]int16 {
+	out := make([][]int16, len(s.raw))
+	for ch, data := range s.raw {
+		conv := make([]int16, len(data))
+		for i, v := range data {
+			conv[i] = int16(v)
+		}
+		out[ch] = conv
+	}
+	return out
+}
+
+// SamplesInt24 returns the decoded samples sign-extended to int32,
+// for 24-bit (and narrower integer PCM) sources.
+func (s Samples) SamplesInt24() [][]int32 {
+	out := make([][]int32, len(s.raw))
+	for ch, data := range s.raw {
+		conv := make([]int32, len(data))
+		copy(conv, data)
+		out[ch] = conv
+	}
+	return out
+}
+
+// SamplesFloat32 reinterprets the decoded samples as IEEE 754 32-bit
+// floats; it only makes sense when fmt.AudioFormat == AudioFormatIEEEFloat.
+func (s Samples) SamplesFloat32() [][]float32 {
+	out := make([][]float32, len(s.raw))
+	for ch, data := range s.raw {
+		conv := make([]float32, len(data))
+		for i, v := range data {
+			conv[i] = math.Float32frombits(uint32(v))
+		}
+		out[ch] = conv
+	}
+	return out
+}
@@ -0,0 +1,126 @@
+// Package generator produces synthetic SampleSources (silence, tones,
+// noise) for placeholder and test WAVs, without ever allocating the
+// full PCM buffer up front: frames are generated on demand as the
+// encoder pulls them.
+package generator
+
+import (
+	"io"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/ffmiruz/wav"
+)
+
+// maxAmplitude returns the largest magnitude representable by fc's
+// bit depth, used to scale generated waveforms into range.
+func maxAmplitude(fc wav.FmtChunk) int32 {
+	return int32(1)<<(fc.BitsPerSample-1) - 1
+}
+
+// frameCount returns the number of frames d spans at fc's sample rate.
+func frameCount(d time.Duration, fc wav.FmtChunk) int64 {
+	return int64(d.Seconds() * float64(fc.SampleRate))
+}
+
+// silenceSource emits zero-valued frames for a fixed duration.
+type silenceSource struct {
+	fc        wav.FmtChunk
+	remaining int64
+}
+
+// Silence returns a SampleSource that emits d worth of zero-valued
+// frames in fc's format.
+func Silence(d time.Duration, fc wav.FmtChunk) wav.SampleSource {
+	return &silenceSource{fc: fc, remaining: frameCount(d, fc)}
+}
+
+func (s *silenceSource) Format() wav.FmtChunk { return s.fc }
+
+func (s *silenceSource) ReadSamples(dst [][]int32) (int, error) {
+	n := len(dst[0])
+	if int64(n) > s.remaining {
+		n = int(s.remaining)
+	}
+	if n == 0 {
+		return 0, io.EOF
+	}
+	for ch := range dst {
+		for i := 0; i < n; i++ {
+			dst[ch][i] = 0
+		}
+	}
+	s.remaining -= int64(n)
+	return n, nil
+}
+
+// sineSource emits a fixed-frequency tone for a fixed duration.
+type sineSource struct {
+	fc        wav.FmtChunk
+	freq      float64
+	remaining int64
+	frame     int64
+}
+
+// Sine returns a SampleSource that emits a freq Hz tone for d, at full
+// scale for fc's bit depth, identical across all of fc's channels.
+func Sine(freq float64, d time.Duration, fc wav.FmtChunk) wav.SampleSource {
+	return &sineSource{fc: fc, freq: freq, remaining: frameCount(d, fc)}
+}
+
+func (s *sineSource) Format() wav.FmtChunk { return s.fc }
+
+func (s *sineSource) ReadSamples(dst [][]int32) (int, error) {
+	n := len(dst[0])
+	if int64(n) > s.remaining {
+		n = int(s.remaining)
+	}
+	if n == 0 {
+		return 0, io.EOF
+	}
+	amp := float64(maxAmplitude(s.fc))
+	step := 2 * math.Pi * s.freq / float64(s.fc.SampleRate)
+	for i := 0; i < n; i++ {
+		v := int32(amp * math.Sin(step*float64(s.frame+int64(i))))
+		for ch := range dst {
+			dst[ch][i] = v
+		}
+	}
+	s.frame += int64(n)
+	s.remaining -= int64(n)
+	return n, nil
+}
+
+// noiseSource emits uniform white noise for a fixed duration.
+type noiseSource struct {
+	fc        wav.FmtChunk
+	rng       *rand.Rand
+	remaining int64
+}
+
+// WhiteNoise returns a SampleSource that emits d worth of uniform
+// white noise at full scale for fc's bit depth.
+func WhiteNoise(d time.Duration, fc wav.FmtChunk) wav.SampleSource {
+	return &noiseSource{fc: fc, rng: rand.New(rand.NewSource(1)), remaining: frameCount(d, fc)}
+}
+
+func (s *noiseSource) Format() wav.FmtChunk { return s.fc }
+
+func (s *noiseSource) ReadSamples(dst [][]int32) (int, error) {
+	n := len(dst[0])
+	if int64(n) > s.remaining {
+		n = int(s.remaining)
+	}
+	if n == 0 {
+		return 0, io.EOF
+	}
+	amp := int64(maxAmplitude(s.fc))
+	for i := 0; i < n; i++ {
+		for ch := range dst {
+			dst[ch][i] = int32(s.rng.Int63n(2*amp+1) - amp)
+		}
+	}
+	s.remaining -= int64(n)
+	return n, nil
+}
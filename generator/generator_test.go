@@ -0,0 +1,65 @@
+package generator
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ffmiruz/wav"
+)
+
+func testFmt(bits uint16) wav.FmtChunk {
+	return wav.FmtChunk{
+		AudioFormat:   wav.AudioFormatPCM,
+		Channel:       2,
+		SampleRate:    1000,
+		BitsPerSample: bits,
+	}
+}
+
+func drain(t *testing.T, src wav.SampleSource, wantFrames int) {
+	t.Helper()
+	fc := src.Format()
+	block := make([][]int32, fc.Channel)
+	for i := range block {
+		block[i] = make([]int32, 64)
+	}
+	got := 0
+	for {
+		n, err := src.ReadSamples(block)
+		got += n
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ReadSamples: %v", err)
+		}
+	}
+	if got != wantFrames {
+		t.Fatalf("got %d frames, want %d", got, wantFrames)
+	}
+}
+
+func TestSilence(t *testing.T) {
+	fc := testFmt(16)
+	src := Silence(10*time.Millisecond, fc)
+	drain(t, src, 10)
+}
+
+func TestSine(t *testing.T) {
+	fc := testFmt(16)
+	src := Sine(100, 10*time.Millisecond, fc)
+	drain(t, src, 10)
+}
+
+func TestWhiteNoise(t *testing.T) {
+	for _, bits := range []uint16{8, 16, 24, 32} {
+		bits := bits
+		t.Run(fmt.Sprintf("%dbit", bits), func(t *testing.T) {
+			fc := testFmt(bits)
+			src := WhiteNoise(10*time.Millisecond, fc)
+			drain(t, src, 10)
+		})
+	}
+}
@@ -0,0 +1,539 @@
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ASCII string "LIST"
+const list_TAG uint32 = 0x4c495354
+
+// ASCII string "bext"
+const bext_TAG uint32 = 0x62657874
+
+// ASCII string "cue "
+const cue_TAG uint32 = 0x63756520
+
+// ASCII string "smpl"
+const smpl_TAG uint32 = 0x736d706c
+
+// ASCII string "plst"
+const plst_TAG uint32 = 0x706c7374
+
+// ASCII string "INFO"
+const info_LIST_TYPE uint32 = 0x494e464f
+
+// RawChunk is a RIFF sub-chunk File doesn't otherwise understand,
+// kept verbatim so re-encoding a file doesn't lose it.
+type RawChunk struct {
+	ID   [4]byte
+	Body []byte
+}
+
+// BextChunk is the EBU "bext" (Broadcast Wave) chunk, as specified in
+// EBU Tech 3285. TimeReference is the origin time of the file in
+// samples. Fields past Version 0 (LoudnessValue onward) are zero if
+// the source file predates BS.1770 loudness metadata.
+type BextChunk struct {
+	Description         string
+	Originator          string
+	OriginatorReference string
+	OriginationDate     string // "YYYY-MM-DD"
+	OriginationTime     string // "HH:MM:SS"
+	TimeReference       uint64
+	Version             uint16
+	UMID                [64]byte
+
+	LoudnessValue        int16
+	LoudnessRange        int16
+	MaxTruePeakLevel     int16
+	MaxMomentaryLoudness int16
+	MaxShortTermLoudness int16
+
+	CodingHistory string
+}
+
+// CuePoint is a single marker from the "cue " chunk.
+type CuePoint struct {
+	ID       uint32
+	Position uint32 // sample offset into the data chunk
+}
+
+// SampleLoop is a single loop record from the "smpl" chunk.
+type SampleLoop struct {
+	ID        uint32
+	Start     uint32
+	End       uint32
+	Fraction  uint32
+	PlayCount uint32
+}
+
+// PlaylistSegment is a single entry of the "plst" chunk, referencing a
+// CuePoint by ID.
+type PlaylistSegment struct {
+	CuePointID  uint32
+	Length      uint32
+	RepeatCount uint32
+}
+
+// Metadata holds the ancillary chunks a DAW or broadcast tool commonly
+// attaches to a WAV file. Info keys are the raw 4-character LIST/INFO
+// tags (INAM, IART, ICMT, ICRD, ...).
+type Metadata struct {
+	Info  map[string]string
+	Bext  *BextChunk
+	Cues  []CuePoint
+	Loops []SampleLoop
+	Plst  []PlaylistSegment
+}
+
+// File is a fully read WAVE file: its fmt chunk, the data chunk as
+// undecoded bytes, and every ancillary chunk ReadFile knows how to
+// interpret. Chunks it doesn't recognize are preserved in Unknown so
+// WriteTo reproduces them byte-for-byte.
+type File struct {
+	FmtChunk
+	DataRaw  []byte
+	Metadata Metadata
+	Unknown  []RawChunk
+
+	// order records the sequence the sub-chunks other than fmt and ds64
+	// appeared in on disk, as one of "data", "info", "bext", "cue ",
+	// "smpl", "plst" or "unknown", so WriteTo can reproduce that order
+	// after the fmt chunk.
+	order []string
+}
+
+// DefaultMaxDataBytes is the data chunk size ReadFile refuses to
+// exceed unless called through ReadFileLimit with an explicit larger
+// limit. File exists for DAW-authored files with LIST/bext/cue
+// metadata, not as a replacement for Decoder on multi-GB audio; the
+// limit keeps ReadFile from silently buffering such a file into
+// memory.
+const DefaultMaxDataBytes = 64 << 20 // 64 MiB
+
+// ReadFile reads a complete WAVE file from r, decoding every chunk it
+// recognizes and preserving the rest verbatim. It is a convenience for
+// files up to DefaultMaxDataBytes of audio data; use NewDecoder
+// directly to stream larger files without buffering them.
+func ReadFile(r io.Reader) (File, error) {
+	return ReadFileLimit(r, DefaultMaxDataBytes)
+}
+
+// readChunkBody reads a sub-chunk's body fully into memory, rejecting
+// any chunk (including data) whose declared size exceeds maxDataBytes
+// so a malformed or malicious size field can't force an oversized
+// allocation. size is a uint64 rather than the on-disk uint32 so
+// callers can pass a ds64-resolved RF64/BW64 data size through the
+// same check.
+func readChunkBody(body io.Reader, id [4]byte, size uint64, maxDataBytes int64) ([]byte, error) {
+	if size > uint64(maxDataBytes) {
+		return nil, fmt.Errorf("wav: %q chunk of %d bytes exceeds %d byte limit; use Decoder to stream large files", id, size, maxDataBytes)
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(body, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// ReadFileLimit is ReadFile with an explicit cap, in bytes, on the
+// size of the data chunk it will read into memory. It returns an
+// error instead of buffering a data chunk larger than maxDataBytes.
+func ReadFileLimit(r io.Reader, maxDataBytes int64) (File, error) {
+	cr, err := NewChunkReader(r)
+	if err != nil {
+		return File{}, err
+	}
+	f := File{Metadata: Metadata{Info: map[string]string{}}}
+	var ds64 *ds64Chunk
+	for {
+		id, size, body, err := cr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return File{}, err
+		}
+		switch {
+		case idEq(id, ds64_TAG):
+			ds64 = &ds64Chunk{}
+			if err := ds64.unmarshal(body); err != nil {
+				return File{}, err
+			}
+		case idEq(id, fmt_TAG):
+			buf, err := readChunkBody(body, id, uint64(size), maxDataBytes)
+			if err != nil {
+				return File{}, err
+			}
+			if err := f.FmtChunk.unmarshal(buf); err != nil {
+				return File{}, err
+			}
+		case idEq(id, data_TAG):
+			dataSize := uint64(size)
+			if size == 0xFFFFFFFF && ds64 != nil {
+				dataSize = ds64.DataSize
+				cr.override64(ds64.DataSize)
+			}
+			buf, err := readChunkBody(body, id, dataSize, maxDataBytes)
+			if err != nil {
+				return File{}, err
+			}
+			f.DataRaw = buf
+			f.order = append(f.order, "data")
+		case idEq(id, list_TAG):
+			buf, err := readChunkBody(body, id, uint64(size), maxDataBytes)
+			if err != nil {
+				return File{}, err
+			}
+			if len(buf) >= 4 && binary.BigEndian.Uint32(buf[0:4]) == info_LIST_TYPE {
+				parseInfo(buf[4:], f.Metadata.Info)
+				f.order = append(f.order, "info")
+			} else {
+				f.Unknown = append(f.Unknown, RawChunk{ID: id, Body: buf})
+				f.order = append(f.order, "unknown")
+			}
+		case idEq(id, bext_TAG):
+			buf, err := readChunkBody(body, id, uint64(size), maxDataBytes)
+			if err != nil {
+				return File{}, err
+			}
+			f.Metadata.Bext = parseBext(buf)
+			f.order = append(f.order, "bext")
+		case idEq(id, cue_TAG):
+			buf, err := readChunkBody(body, id, uint64(size), maxDataBytes)
+			if err != nil {
+				return File{}, err
+			}
+			f.Metadata.Cues = parseCue(buf)
+			f.order = append(f.order, "cue ")
+		case idEq(id, smpl_TAG):
+			buf, err := readChunkBody(body, id, uint64(size), maxDataBytes)
+			if err != nil {
+				return File{}, err
+			}
+			f.Metadata.Loops = parseSmpl(buf)
+			f.order = append(f.order, "smpl")
+		case idEq(id, plst_TAG):
+			buf, err := readChunkBody(body, id, uint64(size), maxDataBytes)
+			if err != nil {
+				return File{}, err
+			}
+			f.Metadata.Plst = parsePlst(buf)
+			f.order = append(f.order, "plst")
+		default:
+			buf, err := readChunkBody(body, id, uint64(size), maxDataBytes)
+			if err != nil {
+				return File{}, err
+			}
+			f.Unknown = append(f.Unknown, RawChunk{ID: id, Body: buf})
+			f.order = append(f.order, "unknown")
+		}
+	}
+	return f, nil
+}
+
+// Samples decodes f.DataRaw according to f.FmtChunk.
+func (f File) Samples() (Samples, error) {
+	_, samples, err := ReadAll(bytes.NewReader(f.wrap()))
+	return samples, err
+}
+
+// wrap re-packages f's fmt and data chunks into a minimal in-memory
+// RIFF/WAVE stream so the regular streaming Decoder can be reused to
+// decode DataRaw.
+func (f File) wrap() []byte {
+	fmtSize := f.FmtChunk.chunkSize()
+	dataSize := uint32(len(f.DataRaw))
+	pad := dataSize % 2
+	total := uint32(4) + (8 + fmtSize) + (8 + dataSize + pad)
+
+	buf := make([]byte, 8+total)
+	Header{Id: RIFF_TAG, Size: total, Format: WAVE_TAG}.marshal(buf[0:12])
+	binary.BigEndian.PutUint32(buf[12:16], fmt_TAG)
+	binary.LittleEndian.PutUint32(buf[16:20], fmtSize)
+	f.FmtChunk.marshal(buf[20 : 20+fmtSize])
+	dataAt := 20 + fmtSize
+	binary.BigEndian.PutUint32(buf[dataAt:dataAt+4], data_TAG)
+	binary.LittleEndian.PutUint32(buf[dataAt+4:dataAt+8], dataSize)
+	copy(buf[dataAt+8:], f.DataRaw)
+	return buf
+}
+
+// WriteTo writes f back out as a complete RIFF/WAVE stream, preserving
+// the original order of the chunks after fmt and any chunks it didn't
+// understand. The fmt chunk is always written first, so a chunk that
+// preceded it on disk is written after it.
+func (f File) WriteTo(w io.Writer) (int64, error) {
+	var chunks bytes.Buffer
+	unknown := f.Unknown
+	for _, kind := range f.order {
+		switch kind {
+		case "data":
+			writeChunk(&chunks, data_TAG, f.DataRaw)
+		case "info":
+			writeChunk(&chunks, list_TAG, marshalInfo(f.Metadata.Info))
+		case "bext":
+			writeChunk(&chunks, bext_TAG, marshalBext(f.Metadata.Bext))
+		case "cue ":
+			writeChunk(&chunks, cue_TAG, marshalCue(f.Metadata.Cues))
+		case "smpl":
+			writeChunk(&chunks, smpl_TAG, marshalSmpl(f.Metadata.Loops))
+		case "plst":
+			writeChunk(&chunks, plst_TAG, marshalPlst(f.Metadata.Plst))
+		case "unknown":
+			raw := unknown[0]
+			unknown = unknown[1:]
+			writeChunk(&chunks, binary.BigEndian.Uint32(raw.ID[:]), raw.Body)
+		}
+	}
+
+	fmtSize := f.FmtChunk.chunkSize()
+	fmtBody := make([]byte, fmtSize)
+	f.FmtChunk.marshal(fmtBody)
+
+	var body bytes.Buffer
+	writeChunk(&body, fmt_TAG, fmtBody)
+	body.Write(chunks.Bytes())
+
+	hdr := make([]byte, 12)
+	Header{Id: RIFF_TAG, Size: uint32(4 + body.Len()), Format: WAVE_TAG}.marshal(hdr)
+
+	n1, err := w.Write(hdr)
+	if err != nil {
+		return int64(n1), err
+	}
+	n2, err := w.Write(body.Bytes())
+	return int64(n1 + n2), err
+}
+
+func writeChunk(w *bytes.Buffer, tag uint32, body []byte) {
+	idBuf := make([]byte, 8)
+	binary.BigEndian.PutUint32(idBuf[0:4], tag)
+	binary.LittleEndian.PutUint32(idBuf[4:8], uint32(len(body)))
+	w.Write(idBuf)
+	w.Write(body)
+	if len(body)%2 == 1 {
+		w.WriteByte(0)
+	}
+}
+
+func nulString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(string(b))
+}
+
+func putNulString(dst []byte, s string) {
+	copy(dst, s)
+}
+
+func parseInfo(buf []byte, out map[string]string) {
+	for len(buf) >= 8 {
+		id := string(buf[0:4])
+		size := binary.LittleEndian.Uint32(buf[4:8])
+		buf = buf[8:]
+		if uint32(len(buf)) < size {
+			return
+		}
+		out[id] = nulString(buf[:size])
+		adv := int(size)
+		if size%2 == 1 {
+			adv++
+		}
+		if adv > len(buf) {
+			return
+		}
+		buf = buf[adv:]
+	}
+}
+
+func marshalInfo(info map[string]string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("INFO")
+	for id, val := range info {
+		v := []byte(val)
+		hdr := make([]byte, 8)
+		copy(hdr[0:4], id)
+		binary.LittleEndian.PutUint32(hdr[4:8], uint32(len(v)+1))
+		buf.Write(hdr)
+		buf.Write(v)
+		buf.WriteByte(0)
+		if len(v)%2 == 0 {
+			buf.WriteByte(0)
+		}
+	}
+	return buf.Bytes()
+}
+
+func parseBext(buf []byte) *BextChunk {
+	get := func(off, n int) []byte {
+		if off+n > len(buf) {
+			return nil
+		}
+		return buf[off : off+n]
+	}
+	b := &BextChunk{
+		Description:         nulString(get(0, 256)),
+		Originator:          nulString(get(256, 32)),
+		OriginatorReference: nulString(get(288, 32)),
+		OriginationDate:     nulString(get(320, 10)),
+		OriginationTime:     nulString(get(330, 8)),
+	}
+	if v := get(338, 4); v != nil {
+		b.TimeReference = uint64(binary.LittleEndian.Uint32(v))
+	}
+	if v := get(342, 4); v != nil {
+		b.TimeReference |= uint64(binary.LittleEndian.Uint32(v)) << 32
+	}
+	if v := get(346, 2); v != nil {
+		b.Version = binary.LittleEndian.Uint16(v)
+	}
+	if v := get(348, 64); v != nil {
+		copy(b.UMID[:], v)
+	}
+	if v := get(412, 2); v != nil {
+		b.LoudnessValue = int16(binary.LittleEndian.Uint16(v))
+	}
+	if v := get(414, 2); v != nil {
+		b.LoudnessRange = int16(binary.LittleEndian.Uint16(v))
+	}
+	if v := get(416, 2); v != nil {
+		b.MaxTruePeakLevel = int16(binary.LittleEndian.Uint16(v))
+	}
+	if v := get(418, 2); v != nil {
+		b.MaxMomentaryLoudness = int16(binary.LittleEndian.Uint16(v))
+	}
+	if v := get(420, 2); v != nil {
+		b.MaxShortTermLoudness = int16(binary.LittleEndian.Uint16(v))
+	}
+	const codingHistoryAt = 602 // past the 180-byte reserved block
+	if codingHistoryAt < len(buf) {
+		b.CodingHistory = strings.TrimRight(string(buf[codingHistoryAt:]), "\x00")
+	}
+	return b
+}
+
+func marshalBext(b *BextChunk) []byte {
+	if b == nil {
+		b = &BextChunk{}
+	}
+	buf := make([]byte, 602+len(b.CodingHistory))
+	putNulString(buf[0:256], b.Description)
+	putNulString(buf[256:288], b.Originator)
+	putNulString(buf[288:320], b.OriginatorReference)
+	putNulString(buf[320:330], b.OriginationDate)
+	putNulString(buf[330:338], b.OriginationTime)
+	binary.LittleEndian.PutUint32(buf[338:342], uint32(b.TimeReference))
+	binary.LittleEndian.PutUint32(buf[342:346], uint32(b.TimeReference>>32))
+	binary.LittleEndian.PutUint16(buf[346:348], b.Version)
+	copy(buf[348:412], b.UMID[:])
+	binary.LittleEndian.PutUint16(buf[412:414], uint16(b.LoudnessValue))
+	binary.LittleEndian.PutUint16(buf[414:416], uint16(b.LoudnessRange))
+	binary.LittleEndian.PutUint16(buf[416:418], uint16(b.MaxTruePeakLevel))
+	binary.LittleEndian.PutUint16(buf[418:420], uint16(b.MaxMomentaryLoudness))
+	binary.LittleEndian.PutUint16(buf[420:422], uint16(b.MaxShortTermLoudness))
+	copy(buf[602:], b.CodingHistory)
+	return buf
+}
+
+func parseCue(buf []byte) []CuePoint {
+	if len(buf) < 4 {
+		return nil
+	}
+	count := binary.LittleEndian.Uint32(buf[0:4])
+	buf = buf[4:]
+	cues := make([]CuePoint, 0, count)
+	for i := uint32(0); i < count && len(buf) >= 24; i++ {
+		cues = append(cues, CuePoint{
+			ID:       binary.LittleEndian.Uint32(buf[0:4]),
+			Position: binary.LittleEndian.Uint32(buf[20:24]),
+		})
+		buf = buf[24:]
+	}
+	return cues
+}
+
+func marshalCue(cues []CuePoint) []byte {
+	buf := make([]byte, 4+24*len(cues))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(cues)))
+	for i, c := range cues {
+		off := 4 + 24*i
+		binary.LittleEndian.PutUint32(buf[off:off+4], c.ID)
+		binary.LittleEndian.PutUint32(buf[off+4:off+8], c.Position)
+		binary.BigEndian.PutUint32(buf[off+8:off+12], data_TAG)
+		// ChunkStart/BlockStart left at 0: this module only ever
+		// produces a single data chunk starting at offset 0.
+		binary.LittleEndian.PutUint32(buf[off+20:off+24], c.Position)
+	}
+	return buf
+}
+
+func parseSmpl(buf []byte) []SampleLoop {
+	if len(buf) < 36 {
+		return nil
+	}
+	count := binary.LittleEndian.Uint32(buf[28:32])
+	buf = buf[36:]
+	loops := make([]SampleLoop, 0, count)
+	for i := uint32(0); i < count && len(buf) >= 24; i++ {
+		loops = append(loops, SampleLoop{
+			ID:        binary.LittleEndian.Uint32(buf[0:4]),
+			Start:     binary.LittleEndian.Uint32(buf[8:12]),
+			End:       binary.LittleEndian.Uint32(buf[12:16]),
+			Fraction:  binary.LittleEndian.Uint32(buf[16:20]),
+			PlayCount: binary.LittleEndian.Uint32(buf[20:24]),
+		})
+		buf = buf[24:]
+	}
+	return loops
+}
+
+func marshalSmpl(loops []SampleLoop) []byte {
+	buf := make([]byte, 36+24*len(loops))
+	binary.LittleEndian.PutUint32(buf[28:32], uint32(len(loops)))
+	for i, l := range loops {
+		off := 36 + 24*i
+		binary.LittleEndian.PutUint32(buf[off:off+4], l.ID)
+		// Type left at 0 (loop forward), the common case.
+		binary.LittleEndian.PutUint32(buf[off+8:off+12], l.Start)
+		binary.LittleEndian.PutUint32(buf[off+12:off+16], l.End)
+		binary.LittleEndian.PutUint32(buf[off+16:off+20], l.Fraction)
+		binary.LittleEndian.PutUint32(buf[off+20:off+24], l.PlayCount)
+	}
+	return buf
+}
+
+func parsePlst(buf []byte) []PlaylistSegment {
+	if len(buf) < 4 {
+		return nil
+	}
+	count := binary.LittleEndian.Uint32(buf[0:4])
+	buf = buf[4:]
+	segs := make([]PlaylistSegment, 0, count)
+	for i := uint32(0); i < count && len(buf) >= 12; i++ {
+		segs = append(segs, PlaylistSegment{
+			CuePointID:  binary.LittleEndian.Uint32(buf[0:4]),
+			Length:      binary.LittleEndian.Uint32(buf[4:8]),
+			RepeatCount: binary.LittleEndian.Uint32(buf[8:12]),
+		})
+		buf = buf[12:]
+	}
+	return segs
+}
+
+func marshalPlst(segs []PlaylistSegment) []byte {
+	buf := make([]byte, 4+12*len(segs))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(segs)))
+	for i, s := range segs {
+		off := 4 + 12*i
+		binary.LittleEndian.PutUint32(buf[off:off+4], s.CuePointID)
+		binary.LittleEndian.PutUint32(buf[off+4:off+8], s.Length)
+		binary.LittleEndian.PutUint32(buf[off+8:off+12], s.RepeatCount)
+	}
+	return buf
+}
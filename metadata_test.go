@@ -0,0 +1,121 @@
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestFileRoundTripMetadata(t *testing.T) {
+	f := File{
+		FmtChunk: FmtChunk{AudioFormat: AudioFormatPCM, Channel: 1, SampleRate: 8000, BitsPerSample: 16, BlockAlign: 2, ByteRate: 16000},
+		DataRaw:  []byte{1, 2, 3, 4, 5, 6},
+		Metadata: Metadata{
+			Info:  map[string]string{"INAM": "test tone"},
+			Bext:  &BextChunk{Description: "desc", Originator: "wav pkg", TimeReference: 12345},
+			Cues:  []CuePoint{{ID: 1, Position: 2}},
+			Loops: []SampleLoop{{ID: 1, Start: 0, End: 3, PlayCount: 1}},
+		},
+		order: []string{"info", "bext", "cue ", "smpl", "data"},
+	}
+
+	var buf bytes.Buffer
+	if _, err := f.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+
+	got, err := ReadFile(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	if got.Metadata.Info["INAM"] != "test tone" {
+		t.Fatalf("Info[INAM] = %q, want %q", got.Metadata.Info["INAM"], "test tone")
+	}
+	if got.Metadata.Bext == nil || got.Metadata.Bext.Description != "desc" || got.Metadata.Bext.TimeReference != 12345 {
+		t.Fatalf("Bext = %+v, want Description=desc TimeReference=12345", got.Metadata.Bext)
+	}
+	if len(got.Metadata.Cues) != 1 || got.Metadata.Cues[0].Position != 2 {
+		t.Fatalf("Cues = %+v", got.Metadata.Cues)
+	}
+	if len(got.Metadata.Loops) != 1 || got.Metadata.Loops[0].End != 3 {
+		t.Fatalf("Loops = %+v", got.Metadata.Loops)
+	}
+	if !bytes.Equal(got.DataRaw, f.DataRaw) {
+		t.Fatalf("DataRaw = %v, want %v", got.DataRaw, f.DataRaw)
+	}
+}
+
+func TestFileUnknownChunkPreserved(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	buf.Write(make([]byte, 4))
+	buf.WriteString("WAVE")
+	fmtBody := make([]byte, 16)
+	FmtChunk{AudioFormat: AudioFormatPCM, Channel: 1, SampleRate: 8000, BitsPerSample: 16}.marshal(fmtBody)
+	writeTestChunk(&buf, "fmt ", fmtBody)
+	writeTestChunk(&buf, "xtra", []byte("custom tool data"))
+	writeTestChunk(&buf, "data", []byte{1, 2, 3, 4})
+
+	f, err := ReadFile(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if len(f.Unknown) != 1 || string(f.Unknown[0].ID[:]) != "xtra" {
+		t.Fatalf("Unknown = %+v, want one xtra chunk", f.Unknown)
+	}
+
+	var out bytes.Buffer
+	if _, err := f.WriteTo(&out); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	if !bytes.Contains(out.Bytes(), []byte("custom tool data")) {
+		t.Fatal("WriteTo dropped the unknown chunk's body")
+	}
+}
+
+func TestReadFileRejectsOversizedData(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	buf.Write(make([]byte, 4))
+	buf.WriteString("WAVE")
+	fmtBody := make([]byte, 16)
+	FmtChunk{AudioFormat: AudioFormatPCM, Channel: 1, SampleRate: 8000, BitsPerSample: 16}.marshal(fmtBody)
+	writeTestChunk(&buf, "fmt ", fmtBody)
+	writeTestChunk(&buf, "data", make([]byte, 1024))
+
+	if _, err := ReadFileLimit(bytes.NewReader(buf.Bytes()), 16); err == nil {
+		t.Fatal("ReadFileLimit succeeded with a data chunk over the limit, want error")
+	}
+}
+
+func TestReadFileLimitResolvesRF64DataSize(t *testing.T) {
+	pcm := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	fc := FmtChunk{AudioFormat: AudioFormatPCM, Channel: 1, SampleRate: 8000, BitsPerSample: 16, BlockAlign: 2, ByteRate: 16000}
+	fmtBody := make([]byte, 16)
+	fc.marshal(fmtBody)
+
+	ds64Body := make([]byte, 28)
+	binary.LittleEndian.PutUint64(ds64Body[8:16], uint64(len(pcm)))
+
+	var buf bytes.Buffer
+	buf.WriteString("RF64")
+	binary.Write(&buf, binary.LittleEndian, uint32(0xFFFFFFFF))
+	buf.WriteString("WAVE")
+	writeTestChunk(&buf, "ds64", ds64Body)
+	writeTestChunk(&buf, "fmt ", fmtBody)
+
+	// The data chunk's on-disk size is the RF64 sentinel; writeTestChunk
+	// always derives size from len(body), so build this one by hand.
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(0xFFFFFFFF))
+	buf.Write(pcm)
+
+	f, err := ReadFileLimit(bytes.NewReader(buf.Bytes()), 1<<20)
+	if err != nil {
+		t.Fatalf("ReadFileLimit: %v", err)
+	}
+	if !bytes.Equal(f.DataRaw, pcm) {
+		t.Fatalf("DataRaw = %v, want %v", f.DataRaw, pcm)
+	}
+}
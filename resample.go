@@ -0,0 +1,282 @@
+package wav
+
+import (
+	"io"
+	"math"
+)
+
+// ResampleOptions configures the polyphase resampling filter built by
+// NewResampler.
+type ResampleOptions struct {
+	// HalfWidth is the half-width of the windowed-sinc filter, in
+	// input samples. Larger values give a sharper transition band at
+	// the cost of more compute per output sample. Zero selects a
+	// reasonable default (8).
+	HalfWidth int
+	// KaiserBeta shapes the Kaiser window applied to the sinc filter;
+	// higher values trade a wider transition band for lower stop-band
+	// ripple. Zero selects a reasonable default (6.0, roughly 60dB of
+	// stop-band attenuation).
+	KaiserBeta float64
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// besselI0 evaluates the zeroth-order modified Bessel function of the
+// first kind, used to build the Kaiser window.
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	halfX := x / 2
+	for k := 1; k < 32; k++ {
+		term *= halfX / float64(k)
+		sum += term * term
+	}
+	return sum
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// resampler pulls frames from src and re-times them to targetRate
+// using a polyphase windowed-sinc filter bank, staying pull-based so
+// it composes with the rest of the streaming API without buffering
+// the whole file.
+type resampler struct {
+	src       SampleSource
+	fc        FmtChunk
+	l, m      int // interpolate by l, decimate by m
+	halfWidth int
+	bank      [][]float64 // bank[phase][tap], len(bank) == l
+	history   [][]float64 // per-channel input frames, primed with 2*halfWidth zeros; consumed frames are dropped from the front
+	filled    int         // len(history[ch]) minus the 2*halfWidth priming length
+	srcDone   bool
+	// position of the next output sample, tracked as numerator/l to
+	// avoid floating point drift over long streams.
+	numerator int
+}
+
+// NewResampler wraps src, producing samples at targetRate instead of
+// src.Format().SampleRate. It mirrors the split between decoding and
+// resampling used elsewhere in the ecosystem: callers that don't need
+// a specific rate can skip this and read src directly.
+func NewResampler(src SampleSource, targetRate int, opts ResampleOptions) SampleSource {
+	fc := src.Format()
+	inRate := int(fc.SampleRate)
+	g := gcd(inRate, targetRate)
+	l, m := targetRate/g, inRate/g
+
+	halfWidth := opts.HalfWidth
+	if halfWidth == 0 {
+		halfWidth = 8
+	}
+	beta := opts.KaiserBeta
+	if beta == 0 {
+		beta = 6.0
+	}
+
+	r := &resampler{src: src, fc: fc, l: l, m: m, halfWidth: halfWidth}
+	r.bank = buildPolyphaseBank(l, m, halfWidth, beta)
+
+	r.history = make([][]float64, fc.Channel)
+	for ch := range r.history {
+		r.history[ch] = make([]float64, 2*halfWidth)
+	}
+
+	outFc := fc
+	outFc.SampleRate = uint32(targetRate)
+	if fc.ByteRate != 0 {
+		outFc.ByteRate = uint32(targetRate) * uint32(fc.FrameSize())
+	}
+	r.fc = outFc
+	return r
+}
+
+// buildPolyphaseBank computes l phases of a windowed-sinc low-pass
+// filter scaled to the narrower of the input/output Nyquist rates
+// (i.e. min(1, l/m) as the cutoff relative to the input rate), each
+// with 2*halfWidth taps, normalized to unit DC gain.
+func buildPolyphaseBank(l, m, halfWidth int, beta float64) [][]float64 {
+	cutoff := 1.0
+	if l < m {
+		cutoff = float64(l) / float64(m)
+	}
+	taps := 2 * halfWidth
+	bank := make([][]float64, l)
+	i0Beta := besselI0(beta)
+	for p := 0; p < l; p++ {
+		row := make([]float64, taps)
+		var sum float64
+		// Fractional offset, in input-sample units, of this phase's
+		// output instant past the tap at k==halfWidth-1.
+		frac := float64(p) / float64(l)
+		for k := 0; k < taps; k++ {
+			x := float64(k-halfWidth) + 1 - frac
+			w := besselI0(beta*math.Sqrt(1-math.Pow(float64(k-taps/2)/float64(taps/2), 2))) / i0Beta
+			v := cutoff * sinc(cutoff*x) * w
+			row[k] = v
+			sum += v
+		}
+		if sum != 0 {
+			for k := range row {
+				row[k] /= sum
+			}
+		}
+		bank[p] = row
+	}
+	return bank
+}
+
+func (r *resampler) Format() FmtChunk { return r.fc }
+
+// refill appends up to one block of frames from src to history.
+// ReadSamples drops frames already consumed by every phase.
+func (r *resampler) refill() error {
+	const block = 256
+	buf := make([][]int32, len(r.history))
+	for ch := range buf {
+		buf[ch] = make([]int32, block)
+	}
+	n, err := r.src.ReadSamples(buf)
+	for ch := range r.history {
+		for i := 0; i < n; i++ {
+			r.history[ch] = append(r.history[ch], float64(buf[ch][i]))
+		}
+	}
+	if n > 0 {
+		r.filled += n
+	}
+	if err == io.EOF {
+		r.srcDone = true
+		return nil
+	}
+	return err
+}
+
+func (r *resampler) ReadSamples(dst [][]int32) (int, error) {
+	n := len(dst[0])
+	produced := 0
+	for produced < n {
+		// numerator/l is the current output instant in input-sample
+		// units; need history out to numerator/l + halfWidth.
+		needed := r.numerator/r.l + r.halfWidth + 1
+		for r.filled < needed && !r.srcDone {
+			if err := r.refill(); err != nil {
+				return produced, err
+			}
+		}
+		if r.filled < needed {
+			// Source exhausted and we don't have enough history for
+			// another full window.
+			if produced == 0 {
+				return 0, io.EOF
+			}
+			return produced, nil
+		}
+		phase := r.numerator % r.l
+		base := r.numerator / r.l
+		tapRow := r.bank[phase]
+		for ch, hist := range r.history {
+			var acc float64
+			for k, coeff := range tapRow {
+				acc += coeff * hist[base+k]
+			}
+			dst[ch][produced] = int32(math.Round(acc))
+		}
+		produced++
+		r.numerator += r.m
+
+		// Drop consumed history once it's out of reach of any future
+		// phase, so the ring doesn't grow without bound.
+		drop := r.numerator/r.l - r.halfWidth
+		if drop > 0 {
+			for ch := range r.history {
+				r.history[ch] = r.history[ch][drop:]
+			}
+			r.filled -= drop
+			r.numerator -= drop * r.l
+		}
+	}
+	return produced, nil
+}
+
+// MixMatrix maps an input frame to an output frame: out[i] is the
+// weighted sum over in[j] of matrix[i][j].
+type MixMatrix [][]float64
+
+// MixMonoToStereo duplicates a single channel across two.
+func MixMonoToStereo() MixMatrix {
+	return MixMatrix{{1}, {1}}
+}
+
+// MixStereoToMono averages left and right.
+func MixStereoToMono() MixMatrix {
+	return MixMatrix{{0.5, 0.5}}
+}
+
+// Mix51ToStereo downmixes ITU/WAVE_FORMAT_EXTENSIBLE default 5.1
+// ordering (FL, FR, FC, LFE, BL, BR) to stereo using the ITU-R BS.775
+// coefficients (center and surrounds attenuated by 1/sqrt(2), LFE
+// dropped).
+func Mix51ToStereo() MixMatrix {
+	const c = 0.7071067811865476 // 1/sqrt(2)
+	return MixMatrix{
+		{1, 0, c, 0, c, 0},
+		{0, 1, c, 0, 0, c},
+	}
+}
+
+// remixer applies a MixMatrix to every frame pulled from src.
+type remixer struct {
+	src    SampleSource
+	fc     FmtChunk
+	matrix MixMatrix
+}
+
+// NewRemixer wraps src, applying matrix to every frame: the result has
+// len(matrix) channels, each a weighted sum of src's channels.
+func NewRemixer(src SampleSource, matrix MixMatrix) SampleSource {
+	fc := src.Format()
+	fc.Channel = uint16(len(matrix))
+	// An arbitrary MixMatrix can recombine channels in ways the source's
+	// ChannelMask no longer describes (e.g. a 5.1 mask surviving a
+	// downmix to stereo), so drop the extension rather than carry a
+	// speaker layout that may not match the mixed result.
+	fc.Extension = nil
+	if fc.BlockAlign != 0 {
+		fc.BlockAlign = uint16(fc.FrameSize())
+	}
+	if fc.ByteRate != 0 {
+		fc.ByteRate = fc.SampleRate * uint32(fc.FrameSize())
+	}
+	return &remixer{src: src, fc: fc, matrix: matrix}
+}
+
+func (m *remixer) Format() FmtChunk { return m.fc }
+
+func (m *remixer) ReadSamples(dst [][]int32) (int, error) {
+	n := len(dst[0])
+	in := make([][]int32, len(m.matrix[0]))
+	for ch := range in {
+		in[ch] = make([]int32, n)
+	}
+	got, err := m.src.ReadSamples(in)
+	for i := 0; i < got; i++ {
+		for outCh, weights := range m.matrix {
+			var acc float64
+			for inCh, w := range weights {
+				acc += w * float64(in[inCh][i])
+			}
+			dst[outCh][i] = int32(math.Round(acc))
+		}
+	}
+	return got, err
+}
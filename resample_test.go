@@ -0,0 +1,127 @@
+package wav
+
+import (
+	"io"
+	"testing"
+)
+
+// constSource emits the same frame n times, for exercising Resampler
+// and Remixer without needing a real decoder.
+type constSource struct {
+	fc        FmtChunk
+	frame     []int32
+	remaining int
+}
+
+func (c *constSource) Format() FmtChunk { return c.fc }
+
+func (c *constSource) ReadSamples(dst [][]int32) (int, error) {
+	n := len(dst[0])
+	if n > c.remaining {
+		n = c.remaining
+	}
+	if n == 0 {
+		return 0, io.EOF
+	}
+	for ch := range dst {
+		for i := 0; i < n; i++ {
+			dst[ch][i] = c.frame[ch]
+		}
+	}
+	c.remaining -= n
+	return n, nil
+}
+
+func drainAll(t *testing.T, src SampleSource) [][]int32 {
+	t.Helper()
+	fc := src.Format()
+	out := make([][]int32, fc.Channel)
+	block := make([][]int32, fc.Channel)
+	for ch := range block {
+		block[ch] = make([]int32, 32)
+	}
+	for {
+		n, err := src.ReadSamples(block)
+		for ch := range out {
+			out[ch] = append(out[ch], block[ch][:n]...)
+		}
+		if err == io.EOF {
+			return out
+		}
+		if err != nil {
+			t.Fatalf("ReadSamples: %v", err)
+		}
+	}
+}
+
+func TestRemixerMonoToStereo(t *testing.T) {
+	src := &constSource{fc: FmtChunk{Channel: 1, SampleRate: 8000}, frame: []int32{1000}, remaining: 10}
+	out := drainAll(t, NewRemixer(src, MixMonoToStereo()))
+	if len(out) != 2 {
+		t.Fatalf("got %d channels, want 2", len(out))
+	}
+	for ch := range out {
+		for i, v := range out[ch] {
+			if v != 1000 {
+				t.Fatalf("channel %d frame %d = %d, want 1000", ch, i, v)
+			}
+		}
+	}
+}
+
+func TestRemixerStereoToMono(t *testing.T) {
+	src := &constSource{fc: FmtChunk{Channel: 2, SampleRate: 8000}, frame: []int32{1000, -1000}, remaining: 10}
+	out := drainAll(t, NewRemixer(src, MixStereoToMono()))
+	if len(out) != 1 {
+		t.Fatalf("got %d channels, want 1", len(out))
+	}
+	for _, v := range out[0] {
+		if v != 0 {
+			t.Fatalf("mixed sample = %d, want 0", v)
+		}
+	}
+}
+
+func TestRemixerRecomputesByteRate(t *testing.T) {
+	src := &constSource{fc: FmtChunk{Channel: 2, SampleRate: 8000, BitsPerSample: 16, BlockAlign: 4, ByteRate: 32000}, frame: []int32{1000, -1000}, remaining: 1}
+	fc := NewRemixer(src, MixStereoToMono()).Format()
+	if want := fc.SampleRate * uint32(fc.FrameSize()); fc.ByteRate != want {
+		t.Fatalf("ByteRate = %d, want %d (SampleRate*BlockAlign)", fc.ByteRate, want)
+	}
+}
+
+func TestRemixerDropsStaleChannelMask(t *testing.T) {
+	src := &constSource{
+		fc: FmtChunk{
+			Channel: 6, SampleRate: 8000, BitsPerSample: 16, BlockAlign: 12, ByteRate: 96000,
+			Extension: &FmtExtension{ValidBitsPerSample: 16, ChannelMask: 0x3F},
+		},
+		frame:     []int32{1000, -1000, 500, 0, 200, -200},
+		remaining: 1,
+	}
+	fc := NewRemixer(src, Mix51ToStereo()).Format()
+	if fc.Extension != nil {
+		t.Fatalf("Extension = %+v, want nil after remixing to a different channel count", fc.Extension)
+	}
+}
+
+func TestResamplerFrameCount(t *testing.T) {
+	const inRate, outRate, inFrames = 8000, 16000, 100
+	src := &constSource{fc: FmtChunk{Channel: 1, SampleRate: inRate}, frame: []int32{0}, remaining: inFrames}
+	out := drainAll(t, NewResampler(src, outRate, ResampleOptions{}))
+
+	want := inFrames * outRate / inRate
+	// The polyphase filter needs a little history on each side, so
+	// tolerate being short by up to one window width.
+	if len(out[0]) < want-16 || len(out[0]) > want+16 {
+		t.Fatalf("got %d frames, want close to %d", len(out[0]), want)
+	}
+}
+
+func TestResamplerFormatUpdatesRate(t *testing.T) {
+	src := &constSource{fc: FmtChunk{Channel: 1, SampleRate: 8000}, frame: []int32{0}, remaining: 1}
+	r := NewResampler(src, 44100, ResampleOptions{})
+	if r.Format().SampleRate != 44100 {
+		t.Fatalf("SampleRate = %d, want 44100", r.Format().SampleRate)
+	}
+}
@@ -0,0 +1,383 @@
+package wav
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// SampleSource is a pull-based source of sample frames. Encoder.WriteFrom,
+// this package's resampler and remixer, and the generator package built
+// on top of it produce and consume SampleSource so that a WAV file can be
+// synthesized or transformed without ever materializing the whole thing
+// in memory.
+type SampleSource interface {
+	// Format returns the fmt sub-chunk describing the samples the
+	// source produces.
+	Format() FmtChunk
+	// ReadSamples fills dst, one slice per channel, with up to
+	// len(dst[0]) frames and returns the number of frames read. It
+	// returns io.EOF once no more frames are available, following the
+	// same "may return n > 0 and err == io.EOF" contract as io.Reader.
+	ReadSamples(dst [][]int32) (int, error)
+}
+
+// chunkLimiter is an io.Reader bounded to the remaining bytes of a
+// single RIFF sub-chunk body.
+type chunkLimiter struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (c *chunkLimiter) Read(p []byte) (int, error) {
+	if c.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	n, err := c.r.Read(p)
+	c.remaining -= int64(n)
+	return n, err
+}
+
+// ChunkReader walks the RIFF sub-chunks of a WAVE file one at a time,
+// so callers can handle unknown chunks (LIST/INFO, bext, cue, smpl,
+// fact, ...) instead of assuming the file is exactly fmt+data.
+type ChunkReader struct {
+	r        io.Reader
+	Header   Header
+	cur      *chunkLimiter
+	lastSize uint32
+}
+
+// NewChunkReader reads and validates the RIFF/WAVE descriptor at the
+// start of r and returns a ChunkReader positioned at the first
+// sub-chunk.
+func NewChunkReader(r io.Reader) (*ChunkReader, error) {
+	buf := make([]byte, 12)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, errors.New("fail to read header")
+	}
+	cr := &ChunkReader{r: r}
+	if err := cr.Header.unmarshal(buf); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+// Next discards any unread bytes (and padding byte) of the current
+// chunk, then reads the id and size of the next sub-chunk and returns
+// a reader limited to its body. It returns io.EOF once the RIFF list
+// is exhausted.
+func (c *ChunkReader) Next() (id [4]byte, size uint32, body io.Reader, err error) {
+	if c.cur != nil {
+		if c.cur.remaining > 0 {
+			if _, err = io.CopyN(io.Discard, c.r, c.cur.remaining); err != nil {
+				return id, 0, nil, err
+			}
+		}
+		if c.lastSize%2 == 1 {
+			if _, err = io.CopyN(io.Discard, c.r, 1); err != nil {
+				return id, 0, nil, err
+			}
+		}
+	}
+	hdr := make([]byte, 8)
+	if _, err = io.ReadFull(c.r, hdr); err != nil {
+		return id, 0, nil, io.EOF
+	}
+	copy(id[:], hdr[0:4])
+	size = binary.LittleEndian.Uint32(hdr[4:8])
+	c.lastSize = size
+	c.cur = &chunkLimiter{r: c.r, remaining: int64(size)}
+	return id, size, c.cur, nil
+}
+
+func idEq(id [4]byte, tag uint32) bool {
+	return binary.BigEndian.Uint32(id[:]) == tag
+}
+
+// override64 replaces the remaining byte count of the chunk Next()
+// just returned with n, the real 64-bit size resolved from a ds64
+// chunk. It must be called before any bytes of that chunk are read.
+// This is needed because RF64/BW64 files put the sentinel 0xFFFFFFFF
+// in the 32-bit chunk size, so the chunkLimiter Next() built from that
+// literal size would otherwise cap reads at ~4GiB.
+func (c *ChunkReader) override64(n uint64) {
+	c.cur.remaining = int64(n)
+	c.lastSize = uint32(n % 2)
+}
+
+// Decoder streams sample frames out of a WAVE file without reading the
+// whole data chunk into memory up front.
+type Decoder struct {
+	cr          *ChunkReader
+	fmtChunk    FmtChunk
+	data        io.Reader
+	remaining64 uint64 // bytes left unread in the data chunk
+}
+
+// NewDecoder reads the RIFF/WAVE header and fmt chunk from r and
+// positions the Decoder at the start of the data chunk, skipping any
+// other chunks it encounters along the way (e.g. LIST, fact).
+func NewDecoder(r io.Reader) (*Decoder, error) {
+	cr, err := NewChunkReader(r)
+	if err != nil {
+		return nil, err
+	}
+	d := &Decoder{cr: cr}
+	haveFmt := false
+	var ds64 *ds64Chunk
+	for {
+		id, size, body, err := cr.Next()
+		if err != nil {
+			return nil, errors.New("fail to find data chunk")
+		}
+		switch {
+		case idEq(id, ds64_TAG):
+			ds64 = &ds64Chunk{}
+			if err := ds64.unmarshal(body); err != nil {
+				return nil, err
+			}
+		case idEq(id, fmt_TAG):
+			buf := make([]byte, size)
+			if _, err := io.ReadFull(body, buf); err != nil {
+				return nil, errors.New("fail to read fmt chunk")
+			}
+			if err := d.fmtChunk.unmarshal(buf); err != nil {
+				return nil, err
+			}
+			haveFmt = true
+		case idEq(id, data_TAG):
+			if !haveFmt {
+				return nil, errors.New("data chunk before fmt chunk")
+			}
+			d.data = body
+			if size == 0xFFFFFFFF && ds64 != nil {
+				d.remaining64 = ds64.DataSize
+				cr.override64(ds64.DataSize)
+			} else {
+				d.remaining64 = uint64(size)
+			}
+			return d, nil
+		}
+	}
+}
+
+// Format returns the fmt sub-chunk of the file being decoded.
+func (d *Decoder) Format() FmtChunk {
+	return d.fmtChunk
+}
+
+// ReadSamples reads up to n frames into dst, one slice per channel
+// (each must have length >= n), and returns the number of frames
+// read. It returns io.EOF once the data chunk is exhausted.
+func (d *Decoder) ReadSamples(dst [][]int32, n int) (int, error) {
+	frameSize := d.fmtChunk.FrameSize()
+	if frameSize == 0 {
+		return 0, errors.New("unsupported fmt chunk")
+	}
+	avail := int(d.remaining64 / uint64(frameSize))
+	if n > avail {
+		n = avail
+	}
+	if n == 0 {
+		return 0, io.EOF
+	}
+	companded := d.fmtChunk.AudioFormat == AudioFormatALaw || d.fmtChunk.AudioFormat == AudioFormatMULaw
+	buf := make([]byte, frameSize)
+	for frame := 0; frame < n; frame++ {
+		if _, err := io.ReadFull(d.data, buf); err != nil {
+			return frame, errors.New("fail to read sample data")
+		}
+		d.remaining64 -= uint64(frameSize)
+		bps := d.fmtChunk.BytesPerSample()
+		for ch := 0; ch < int(d.fmtChunk.Channel); ch++ {
+			off := ch * bps
+			switch {
+			case companded && d.fmtChunk.BitsPerSample == 8:
+				if d.fmtChunk.AudioFormat == AudioFormatALaw {
+					dst[ch][frame] = int32(alawDecode(buf[off]))
+				} else {
+					dst[ch][frame] = int32(mulawDecode(buf[off]))
+				}
+			case d.fmtChunk.BitsPerSample == 8:
+				dst[ch][frame] = int32(buf[off]) - 128
+			case d.fmtChunk.BitsPerSample == 16:
+				dst[ch][frame] = int32(int16(binary.LittleEndian.Uint16(buf[off : off+2])))
+			case d.fmtChunk.BitsPerSample == 24:
+				dst[ch][frame] = int32(buf[off])<<8 | int32(buf[off+1])<<16 | int32(buf[off+2])<<24
+				dst[ch][frame] >>= 8 // sign-extend from 24 to 32 bits
+			case d.fmtChunk.BitsPerSample == 32:
+				dst[ch][frame] = int32(binary.LittleEndian.Uint32(buf[off : off+4]))
+			default:
+				return frame, errors.New("unsupported bits per sample")
+			}
+		}
+	}
+	return n, nil
+}
+
+// Encoder streams sample frames into a WAVE file, writing the fmt
+// chunk once up front and the data chunk incrementally. If w is an
+// io.WriteSeeker, Finalize back-patches the RIFF and data chunk sizes;
+// otherwise the caller must supply the total frame count in advance
+// via WriteHeader so the sizes are correct on the first pass.
+type Encoder struct {
+	w           io.Writer
+	ws          io.WriteSeeker
+	fmtChunk    FmtChunk
+	totalFrames uint32 // frame count if known up front, 0 otherwise
+	written     uint32 // frames written so far
+	riffSizeAt  int64
+	dataSizeAt  int64
+}
+
+// NewEncoder wraps w. If w also implements io.WriteSeeker, the
+// Encoder takes advantage of it in Finalize to back-patch accurate
+// chunk sizes.
+func NewEncoder(w io.Writer) *Encoder {
+	e := &Encoder{w: w}
+	if ws, ok := w.(io.WriteSeeker); ok {
+		e.ws = ws
+	}
+	return e
+}
+
+// WriteHeader writes the RIFF/WAVE descriptor and fmt chunk, then
+// opens the data chunk. totalFrames may be 0 if the frame count isn't
+// known up front; in that case w must be an io.WriteSeeker so
+// Finalize can back-patch the sizes once they are known.
+func (e *Encoder) WriteHeader(fc FmtChunk, totalFrames uint32) error {
+	if totalFrames == 0 && e.ws == nil {
+		return errors.New("wav: unknown frame count requires an io.WriteSeeker")
+	}
+	e.fmtChunk = fc
+	e.totalFrames = totalFrames
+	dataSize := totalFrames * uint32(fc.FrameSize())
+	fmtSize := fc.chunkSize()
+
+	buf := make([]byte, 12+8+fmtSize+8)
+	h := Header{Id: RIFF_TAG, Format: WAVE_TAG, Size: uint32(len(buf)-8) + dataSize}
+	h.marshal(buf[0:12])
+	e.riffSizeAt = 4
+
+	binary.BigEndian.PutUint32(buf[12:16], fmt_TAG)
+	binary.LittleEndian.PutUint32(buf[16:20], fmtSize)
+	fc.marshal(buf[20 : 20+fmtSize])
+
+	dataIDAt := 20 + fmtSize
+	binary.BigEndian.PutUint32(buf[dataIDAt:dataIDAt+4], data_TAG)
+	binary.LittleEndian.PutUint32(buf[dataIDAt+4:dataIDAt+8], dataSize)
+	e.dataSizeAt = int64(dataIDAt + 4)
+
+	_, err := e.w.Write(buf)
+	return err
+}
+
+// WriteSamples writes one frame per element of src[0] (src holds one
+// slice per channel, all the same length).
+func (e *Encoder) WriteSamples(src [][]int32) error {
+	if len(src) != int(e.fmtChunk.Channel) {
+		return errors.New("wav: sample source channel count mismatch")
+	}
+	n := len(src[0])
+	bps := e.fmtChunk.BytesPerSample()
+	companded := e.fmtChunk.AudioFormat == AudioFormatALaw || e.fmtChunk.AudioFormat == AudioFormatMULaw
+	buf := make([]byte, n*e.fmtChunk.FrameSize())
+	for frame := 0; frame < n; frame++ {
+		for ch, samples := range src {
+			off := frame*e.fmtChunk.FrameSize() + ch*bps
+			switch {
+			case companded && e.fmtChunk.BitsPerSample == 8:
+				if e.fmtChunk.AudioFormat == AudioFormatALaw {
+					buf[off] = alawEncode(samples[frame])
+				} else {
+					buf[off] = mulawEncode(samples[frame])
+				}
+			case e.fmtChunk.BitsPerSample == 8:
+				buf[off] = byte(samples[frame] + 128)
+			case e.fmtChunk.BitsPerSample == 16:
+				binary.LittleEndian.PutUint16(buf[off:off+2], uint16(int16(samples[frame])))
+			case e.fmtChunk.BitsPerSample == 24:
+				v := uint32(samples[frame])
+				buf[off] = byte(v)
+				buf[off+1] = byte(v >> 8)
+				buf[off+2] = byte(v >> 16)
+			case e.fmtChunk.BitsPerSample == 32:
+				binary.LittleEndian.PutUint32(buf[off:off+4], uint32(samples[frame]))
+			default:
+				return errors.New("unsupported bits per sample")
+			}
+		}
+	}
+	if _, err := e.w.Write(buf); err != nil {
+		return err
+	}
+	e.written += uint32(n)
+	return nil
+}
+
+// WriteFrom drains src and writes every frame it produces.
+func (e *Encoder) WriteFrom(src SampleSource) error {
+	fc := src.Format()
+	chunk := make([][]int32, fc.Channel)
+	const blockFrames = 4096
+	for i := range chunk {
+		chunk[i] = make([]int32, blockFrames)
+	}
+	for {
+		n, err := src.ReadSamples(chunk)
+		if n > 0 {
+			block := make([][]int32, fc.Channel)
+			for i := range block {
+				block[i] = chunk[i][:n]
+			}
+			if werr := e.WriteSamples(block); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Finalize back-patches the RIFF and data chunk sizes when the
+// written frame count wasn't known at WriteHeader time. If totalFrames
+// was already supplied, it writes nothing and only returns an error
+// when the number of frames written differs from it.
+func (e *Encoder) Finalize() error {
+	if e.totalFrames != 0 {
+		if e.written != e.totalFrames {
+			return errors.New("wav: frames written differ from the count declared in WriteHeader")
+		}
+		return nil
+	}
+	dataSize := e.written * uint32(e.fmtChunk.FrameSize())
+	riffSize := uint32(4+8+8) + e.fmtChunk.chunkSize() + dataSize
+
+	buf := make([]byte, 4)
+	binary.LittleEndian.PutUint32(buf, riffSize)
+	if _, err := e.ws.Seek(e.riffSizeAt, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := e.ws.Write(buf); err != nil {
+		return err
+	}
+
+	binary.LittleEndian.PutUint32(buf, dataSize)
+	if _, err := e.ws.Seek(e.dataSizeAt, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := e.ws.Write(buf); err != nil {
+		return err
+	}
+
+	_, err := e.ws.Seek(0, io.SeekEnd)
+	return err
+}
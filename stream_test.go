@@ -0,0 +1,230 @@
+package wav
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// seekBuffer is an in-memory io.WriteSeeker over a growable byte
+// slice, behaving the way *os.File does for Encoder.Finalize.
+type seekBuffer struct {
+	buf []byte
+	pos int64
+}
+
+func (s *seekBuffer) Write(p []byte) (int, error) {
+	end := s.pos + int64(len(p))
+	if end > int64(len(s.buf)) {
+		grown := make([]byte, end)
+		copy(grown, s.buf)
+		s.buf = grown
+	}
+	copy(s.buf[s.pos:end], p)
+	s.pos = end
+	return len(p), nil
+}
+
+func (s *seekBuffer) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+		s.pos = offset
+	case io.SeekCurrent:
+		s.pos += offset
+	case io.SeekEnd:
+		s.pos = int64(len(s.buf)) + offset
+	}
+	return s.pos, nil
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	fc := FmtChunk{
+		AudioFormat:   AudioFormatPCM,
+		Channel:       2,
+		SampleRate:    8000,
+		BitsPerSample: 16,
+		BlockAlign:    4,
+		ByteRate:      32000,
+	}
+
+	out := &seekBuffer{}
+	enc := NewEncoder(out)
+	if err := enc.WriteHeader(fc, 0); err != nil {
+		t.Fatalf("WriteHeader: %v", err)
+	}
+	want := [][]int32{
+		{1, 2, 3, 4},
+		{-1, -2, -3, -4},
+	}
+	if err := enc.WriteSamples(want); err != nil {
+		t.Fatalf("WriteSamples: %v", err)
+	}
+	if err := enc.Finalize(); err != nil {
+		t.Fatalf("Finalize: %v", err)
+	}
+
+	dec, err := NewDecoder(bytes.NewReader(out.buf))
+	if err != nil {
+		t.Fatalf("NewDecoder: %v", err)
+	}
+	if dec.Format().Channel != fc.Channel || dec.Format().SampleRate != fc.SampleRate {
+		t.Fatalf("Format() = %+v, want channel/rate matching %+v", dec.Format(), fc)
+	}
+
+	got := [][]int32{make([]int32, 4), make([]int32, 4)}
+	n, err := dec.ReadSamples(got, 4)
+	if err != nil && err != io.EOF {
+		t.Fatalf("ReadSamples: %v", err)
+	}
+	if n != 4 {
+		t.Fatalf("got %d frames, want 4", n)
+	}
+	for ch := range want {
+		for i := range want[ch] {
+			if got[ch][i] != want[ch][i] {
+				t.Fatalf("frame %d channel %d = %d, want %d", i, ch, got[ch][i], want[ch][i])
+			}
+		}
+	}
+
+	if _, err := dec.ReadSamples(got, 4); err != io.EOF {
+		t.Fatalf("ReadSamples past end: err = %v, want io.EOF", err)
+	}
+}
+
+func TestEncodeDecodeRoundTripALaw(t *testing.T) {
+	fc := FmtChunk{
+		AudioFormat:   AudioFormatALaw,
+		Channel:       1,
+		SampleRate:    8000,
+		BitsPerSample: 8,
+		BlockAlign:    1,
+		ByteRate:      8000,
+	}
+
+	out := &bytes.Buffer{}
+	enc := NewEncoder(out)
+	want := []int32{1000, -1000, 2000, -2000}
+	if err := enc.WriteHeader(fc, uint32(len(want))); err != nil {
+		t.Fatalf("WriteHeader: %v", err)
+	}
+	if err := enc.WriteSamples([][]int32{want}); err != nil {
+		t.Fatalf("WriteSamples: %v", err)
+	}
+
+	dec, err := NewDecoder(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatalf("NewDecoder: %v", err)
+	}
+	got := [][]int32{make([]int32, len(want))}
+	if _, err := dec.ReadSamples(got, len(want)); err != nil && err != io.EOF {
+		t.Fatalf("ReadSamples: %v", err)
+	}
+	for i, w := range want {
+		if d := got[0][i]; abs32(d-w) > 1024 {
+			t.Fatalf("frame %d = %d, want within a segment's quantization step of %d", i, d, w)
+		}
+	}
+}
+
+func TestEncodeDecodeRoundTripExtensibleFinalize(t *testing.T) {
+	fc := FmtChunk{
+		AudioFormat:   AudioFormatPCM,
+		Channel:       2,
+		SampleRate:    8000,
+		BitsPerSample: 16,
+		BlockAlign:    4,
+		ByteRate:      32000,
+		Extension: &FmtExtension{
+			ValidBitsPerSample: 16,
+			ChannelMask:        3,
+		},
+	}
+
+	out := &seekBuffer{}
+	enc := NewEncoder(out)
+	if err := enc.WriteHeader(fc, 0); err != nil {
+		t.Fatalf("WriteHeader: %v", err)
+	}
+	want := [][]int32{
+		{1, 2, 3, 4},
+		{-1, -2, -3, -4},
+	}
+	if err := enc.WriteSamples(want); err != nil {
+		t.Fatalf("WriteSamples: %v", err)
+	}
+	if err := enc.Finalize(); err != nil {
+		t.Fatalf("Finalize: %v", err)
+	}
+
+	var h Header
+	if err := h.unmarshal(out.buf[0:12]); err != nil {
+		t.Fatalf("unmarshal header: %v", err)
+	}
+	wantRiffSize := uint32(len(out.buf) - 8)
+	if h.Size != wantRiffSize {
+		t.Fatalf("riff size = %d, want %d (declared size must match actual trailing bytes)", h.Size, wantRiffSize)
+	}
+
+	dec, err := NewDecoder(bytes.NewReader(out.buf))
+	if err != nil {
+		t.Fatalf("NewDecoder: %v", err)
+	}
+	if dec.Format().Channel != fc.Channel || dec.Format().SampleRate != fc.SampleRate {
+		t.Fatalf("Format() = %+v, want channel/rate matching %+v", dec.Format(), fc)
+	}
+
+	got := [][]int32{make([]int32, 4), make([]int32, 4)}
+	n, err := dec.ReadSamples(got, 4)
+	if err != nil && err != io.EOF {
+		t.Fatalf("ReadSamples: %v", err)
+	}
+	if n != 4 {
+		t.Fatalf("got %d frames, want 4", n)
+	}
+	for ch := range want {
+		for i := range want[ch] {
+			if got[ch][i] != want[ch][i] {
+				t.Fatalf("frame %d channel %d = %d, want %d", i, ch, got[ch][i], want[ch][i])
+			}
+		}
+	}
+}
+
+func TestChunkReaderWalksUnknownChunks(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	var sizePlaceholder [4]byte
+	buf.Write(sizePlaceholder[:])
+	buf.WriteString("WAVE")
+	writeTestChunk(&buf, "LIST", []byte("INFOIART\x05\x00\x00\x00abc\x00"))
+	writeTestChunk(&buf, "fmt ", make([]byte, 16))
+	writeTestChunk(&buf, "data", []byte{1, 2, 3, 4})
+
+	cr, err := NewChunkReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("NewChunkReader: %v", err)
+	}
+
+	var ids []string
+	for {
+		id, _, body, err := cr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		ids = append(ids, string(id[:]))
+		io.Copy(io.Discard, body)
+	}
+	want := []string{"LIST", "fmt ", "data"}
+	if len(ids) != len(want) {
+		t.Fatalf("ids = %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("ids[%d] = %q, want %q", i, ids[i], want[i])
+		}
+	}
+}